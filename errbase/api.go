@@ -0,0 +1,85 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package errbase provides the basic mechanisms that other error
+// packages in this repository build upon: the ability to format an
+// error with a short and a verbose representation, and the ability to
+// turn an error into a network-safe representation and back.
+package errbase
+
+// Formatter is implemented by errors that know how to render both a
+// short form (their Error() string) and a detailed, verbose form for
+// %+v. Types that implement Formatter should forward their
+// fmt.Formatter.Format method to FormatError.
+type Formatter interface {
+	// FormatError prints the receiver's own contribution to the error
+	// chain to p and returns the next error in the chain (its direct
+	// cause), or nil if there is none.
+	FormatError(p Printer) (next error)
+}
+
+// Printer is passed to FormatError implementations so that they can
+// contribute to both the short and the detailed representation of an
+// error.
+type Printer interface {
+	// Print appends its arguments to the message, using the formatting
+	// rules of fmt.Sprint.
+	Print(args ...interface{})
+
+	// Printf appends its arguments to the message, using the
+	// formatting rules of fmt.Sprintf.
+	Printf(format string, args ...interface{})
+
+	// Detail returns true once the printer has entered the verbose
+	// (%+v) rendering pass. Implementations typically only emit
+	// additional detail when this returns true.
+	Detail() bool
+}
+
+// PayloadEncoder can be implemented by leaf or wrapper error types
+// that need to carry additional, type-specific information across
+// EncodeError/DecodeError. The payload is opaque to errbase itself;
+// it is only meaningful to a matching decoder registered with
+// RegisterLeafDecoder or RegisterWrapperDecoder under the same
+// TypeKey.
+type PayloadEncoder interface {
+	error
+
+	// EncodePayload returns the extra data to store alongside the
+	// error's message and type key.
+	EncodePayload() interface{}
+}
+
+// TypeKeyer can be implemented by error types produced by DecodeError
+// itself (see opaqueLeaf, opaqueWrapper) to report the TypeKey of the
+// original error they stand in for, rather than their own Go type.
+// This lets callers keep distinguishing two errors of different,
+// unregistered origin types even after both have lost their concrete
+// Go type across the network.
+type TypeKeyer interface {
+	error
+	ErrbaseTypeKey() TypeKey
+}
+
+// PayloadCarrier can be implemented by error types produced by
+// DecodeError itself (see opaqueLeaf, opaqueWrapper, opaqueJoin) to
+// hand back the original EncodedError.Payload they were decoded with.
+// Because an opaque stand-in has no registered decoder to interpret
+// the payload at DecodeError time, it keeps the payload around
+// unread, so that code such as markers.As can still hand it to a
+// decoder that gets registered afterwards.
+type PayloadCarrier interface {
+	error
+	ErrbasePayload() interface{}
+}
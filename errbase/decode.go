@@ -0,0 +1,144 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"context"
+	"fmt"
+)
+
+// LeafDecoder reconstructs a leaf error (one with no cause) from its
+// message and payload, as registered through RegisterLeafDecoder.
+type LeafDecoder func(msg string, payload interface{}) error
+
+// WrapperDecoder reconstructs a wrapper error from its already
+// decoded cause together with its own message and payload, as
+// registered through RegisterWrapperDecoder.
+type WrapperDecoder func(cause error, msg string, payload interface{}) error
+
+var leafDecoders = map[TypeKey]LeafDecoder{}
+var wrapperDecoders = map[TypeKey]WrapperDecoder{}
+
+// RegisterLeafDecoder registers fn as the reconstructor for leaf
+// errors whose TypeKey is key. It is meant to be called from package
+// init() functions.
+func RegisterLeafDecoder(key TypeKey, fn LeafDecoder) {
+	leafDecoders[key] = fn
+}
+
+// RegisterWrapperDecoder registers fn as the reconstructor for
+// wrapper errors whose TypeKey is key. It is meant to be called from
+// package init() functions.
+func RegisterWrapperDecoder(key TypeKey, fn WrapperDecoder) {
+	wrapperDecoders[key] = fn
+}
+
+// DecodeLeafByKey reconstructs a leaf error of the type identified by
+// key directly from a message and payload, without going through an
+// EncodedError. It reports false if no leaf decoder is registered
+// under key. This is used by markers.As to recover a concrete type
+// from a chain that was decoded into an opaque stand-in because the
+// receiving binary had no decoder registered at DecodeError time but
+// has since gained one (for example, the caller imports the producing
+// package even though the value crossed the network through a generic
+// handler).
+func DecodeLeafByKey(key TypeKey, msg string, payload interface{}) (error, bool) {
+	fn, ok := leafDecoders[key]
+	if !ok {
+		return nil, false
+	}
+	return fn(msg, payload), true
+}
+
+// DecodeError reconstructs an error chain from its network-safe
+// representation. Types with a decoder registered via
+// RegisterLeafDecoder/RegisterWrapperDecoder are rebuilt exactly;
+// every other type decodes into an opaque stand-in that still
+// reports the original TypeKey and message, so that equivalence
+// checks such as markers.Is keep working across the network.
+func DecodeError(ctx context.Context, enc *EncodedError) error {
+	if enc == nil {
+		return nil
+	}
+
+	if len(enc.Causes) > 0 {
+		children := make([]error, len(enc.Causes))
+		for i, c := range enc.Causes {
+			children[i] = DecodeError(ctx, c)
+		}
+		return &opaqueJoin{msg: enc.Msg, key: enc.Key, payload: enc.Payload, errs: children}
+	}
+
+	if enc.Cause != nil {
+		cause := DecodeError(ctx, enc.Cause)
+		if fn, ok := wrapperDecoders[enc.Key]; ok {
+			return fn(cause, enc.Msg, enc.Payload)
+		}
+		return &opaqueWrapper{msg: enc.Msg, key: enc.Key, payload: enc.Payload, cause: cause}
+	}
+
+	if fn, ok := leafDecoders[enc.Key]; ok {
+		return fn(enc.Msg, enc.Payload)
+	}
+	return &opaqueLeaf{msg: enc.Msg, key: enc.Key, payload: enc.Payload}
+}
+
+// opaqueLeaf stands in for a leaf error whose concrete Go type has no
+// registered decoder. It keeps the original payload around, unread,
+// so that DecodeLeafByKey can still hand it to a decoder registered
+// after the fact.
+type opaqueLeaf struct {
+	msg     string
+	key     TypeKey
+	payload interface{}
+}
+
+func (e *opaqueLeaf) Error() string                 { return e.msg }
+func (e *opaqueLeaf) ErrbaseTypeKey() TypeKey       { return e.key }
+func (e *opaqueLeaf) ErrbasePayload() interface{}   { return e.payload }
+func (e *opaqueLeaf) Format(s fmt.State, verb rune) { FormatError(e, s, verb) }
+
+// opaqueWrapper stands in for a single-cause wrapper error whose
+// concrete Go type has no registered decoder. It keeps the original
+// payload around, unread, for the same reason as opaqueLeaf.
+type opaqueWrapper struct {
+	msg     string
+	key     TypeKey
+	payload interface{}
+	cause   error
+}
+
+func (e *opaqueWrapper) Error() string                 { return e.msg }
+func (e *opaqueWrapper) Unwrap() error                 { return e.cause }
+func (e *opaqueWrapper) ErrbaseTypeKey() TypeKey       { return e.key }
+func (e *opaqueWrapper) ErrbasePayload() interface{}   { return e.payload }
+func (e *opaqueWrapper) Format(s fmt.State, verb rune) { FormatError(e, s, verb) }
+
+// opaqueJoin stands in for a multi-cause error (produced locally by
+// errors.Join or fmt.Errorf's multi-%w) whose concrete Go type has no
+// registered decoder. It keeps the original payload around, unread,
+// for the same reason as opaqueLeaf.
+type opaqueJoin struct {
+	msg     string
+	key     TypeKey
+	payload interface{}
+	errs    []error
+}
+
+func (e *opaqueJoin) Error() string                 { return e.msg }
+func (e *opaqueJoin) Unwrap() []error               { return e.errs }
+func (e *opaqueJoin) ErrbaseTypeKey() TypeKey       { return e.key }
+func (e *opaqueJoin) ErrbasePayload() interface{}   { return e.payload }
+func (e *opaqueJoin) Format(s fmt.State, verb rune) { FormatError(e, s, verb) }
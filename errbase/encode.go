@@ -0,0 +1,96 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"context"
+	"reflect"
+)
+
+// TypeKey identifies the concrete Go type of an error for the
+// purposes of encoding, decoding and marker equivalence. It is
+// derived from the type's package path and its Go type string, e.g.
+// "github.com/pkg/errors/*errors.fundamental".
+type TypeKey string
+
+// GetTypeKey returns the TypeKey of err. If err was itself produced
+// by DecodeError and stands in for a type that could not be
+// reconstructed, GetTypeKey returns the TypeKey of the original,
+// pre-encoding error instead of errbase's own opaque wrapper type.
+func GetTypeKey(err error) TypeKey {
+	if tk, ok := err.(TypeKeyer); ok {
+		return tk.ErrbaseTypeKey()
+	}
+	return GetTypeKeyForType(reflect.TypeOf(err))
+}
+
+// GetTypeKeyForType returns the TypeKey that GetTypeKey would compute
+// for a value of type t. It lets callers compute the TypeKey of a
+// target type they merely have a reflect.Type for — for example a
+// zero Go type used only to probe a decoded error chain, as
+// markers.As and markers.HasType do.
+func GetTypeKeyForType(t reflect.Type) TypeKey {
+	pkgPath := t.PkgPath()
+	if pkgPath == "" && t.Kind() == reflect.Ptr {
+		pkgPath = t.Elem().PkgPath()
+	}
+	return TypeKey(pkgPath + "/" + t.String())
+}
+
+// EncodedError is the network-safe representation of an error chain,
+// as produced by EncodeError and consumed by DecodeError.
+type EncodedError struct {
+	// Msg is the result of calling Error() on the original error.
+	Msg string
+	// Key identifies the original error's concrete Go type.
+	Key TypeKey
+	// Cause is the encoded direct cause, for a single-cause wrapper.
+	// It is nil for leaf errors and for multi-cause errors, which use
+	// Causes instead.
+	Cause *EncodedError
+	// Causes holds the encoded direct causes of an error produced by
+	// errors.Join or by fmt.Errorf with multiple %w verbs.
+	Causes []*EncodedError
+	// Payload carries the extra, type-specific data contributed by a
+	// PayloadEncoder, if any.
+	Payload interface{}
+}
+
+// EncodeError encodes err, and transitively its whole chain of
+// causes, into a network-safe representation.
+func EncodeError(ctx context.Context, err error) *EncodedError {
+	if err == nil {
+		return nil
+	}
+
+	key := GetTypeKey(err)
+
+	enc := &EncodedError{Msg: err.Error(), Key: key}
+	if pe, ok := err.(PayloadEncoder); ok {
+		enc.Payload = pe.EncodePayload()
+	}
+
+	if children := UnwrapMulti(err); children != nil {
+		for _, c := range children {
+			enc.Causes = append(enc.Causes, EncodeError(ctx, c))
+		}
+		return enc
+	}
+
+	if cause := UnwrapOnce(err); cause != nil {
+		enc.Cause = EncodeError(ctx, cause)
+	}
+	return enc
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// FormatError implements the common %s/%v/%+v/%q rendering shared by
+// every wrapper and leaf error type in this repository that exposes a
+// Formatter. Callers simply forward their fmt.Formatter.Format method
+// to this function:
+//
+//	func (e *myError) Format(s fmt.State, verb rune) { errbase.FormatError(e, s, verb) }
+func FormatError(err error, s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprint(s, formatVerbose(err))
+			return
+		}
+		fmt.Fprint(s, err.Error())
+	case 's':
+		fmt.Fprint(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+// printer is the concrete Printer passed to FormatError
+// implementations while rendering the verbose (%+v) form. The first
+// call to Detail() inserts a newline, so that whatever a
+// FormatError implementation prints after checking it always starts
+// on its own line, regardless of whether the short message printed
+// before it ended with one.
+type printer struct {
+	buf           bytes.Buffer
+	detailEntered bool
+}
+
+func (p *printer) Print(args ...interface{})                 { fmt.Fprint(&p.buf, args...) }
+func (p *printer) Printf(format string, args ...interface{}) { fmt.Fprintf(&p.buf, format, args...) }
+
+func (p *printer) Detail() bool {
+	if !p.detailEntered {
+		p.detailEntered = true
+		if p.buf.Len() > 0 {
+			p.buf.WriteByte('\n')
+		}
+	}
+	return true
+}
+
+// formatVerbose renders the full chain of err, from outermost to
+// innermost cause, one entry per layer. The outermost entry is
+// printed as-is; every subsequent entry is introduced with a "  - "
+// bullet. Within an entry, the first line receives a trailing ":" if
+// the entry has further, more detailed lines, which are themselves
+// indented by four spaces.
+func formatVerbose(err error) string {
+	var entries [][]string
+	for e := err; e != nil; {
+		if f, ok := e.(Formatter); ok {
+			p := &printer{}
+			next := f.FormatError(p)
+			entries = append(entries, strings.Split(p.buf.String(), "\n"))
+			e = next
+			continue
+		}
+		entries = append(entries, []string{e.Error()})
+		e = UnwrapOnce(e)
+	}
+
+	var buf bytes.Buffer
+	for i, lines := range entries {
+		if i > 0 {
+			buf.WriteString("\n  - ")
+		}
+		first := lines[0]
+		if len(lines) > 1 {
+			first += ":"
+		}
+		buf.WriteString(first)
+		for _, l := range lines[1:] {
+			buf.WriteString("\n    ")
+			buf.WriteString(l)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+// UnwrapOnce returns the direct cause of err, i.e. the result of
+// calling its Unwrap() error method, or nil if err does not implement
+// that method or has no cause. Callers that also need to follow
+// errors.Join-style fan-out should consult UnwrapMulti first.
+func UnwrapOnce(err error) error {
+	type causer interface {
+		Unwrap() error
+	}
+	c, ok := err.(causer)
+	if !ok {
+		return nil
+	}
+	return c.Unwrap()
+}
+
+// UnwrapMulti returns the set of direct causes of err if it
+// implements the `Unwrap() []error` method introduced by errors.Join
+// and by fmt.Errorf's multi-%w support, or nil if it does not.
+func UnwrapMulti(err error) []error {
+	type multiCauser interface {
+		Unwrap() []error
+	}
+	m, ok := err.(multiCauser)
+	if !ok {
+		return nil
+	}
+	return m.Unwrap()
+}
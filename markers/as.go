@@ -0,0 +1,107 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers
+
+import (
+	"reflect"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// As finds the first error in err's chain, including across
+// EncodeError/DecodeError, that matches target's type, and if so,
+// sets target to that error value and returns true. Like the
+// standard library's errors.As, target must be a non-nil pointer to
+// either a type implementing error or to an interface type.
+//
+// Unlike errors.As, As also succeeds on a chain node that was decoded
+// into an opaque stand-in because the receiving binary had no
+// decoder registered for the original type: it recognizes the type by
+// its preserved fingerprint (package path and type name) even though
+// the concrete Go type is not linked in. In that case, target is
+// populated if a decoder has since been registered for that
+// fingerprint, and left zero-valued otherwise; callers that only need
+// to know whether such a type is present, without requiring the
+// value, can use HasType instead.
+func As(err error, target interface{}) bool {
+	if err == nil {
+		return false
+	}
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Kind() != reflect.Ptr || targetVal.IsNil() {
+		panic("errors: target must be a non-nil pointer")
+	}
+	targetType := targetVal.Elem().Type()
+	targetKey := errbase.GetTypeKeyForType(targetType)
+
+	found := false
+	Walk(err, func(e error) bool {
+		if et := reflect.TypeOf(e); et != nil && et.AssignableTo(targetType) {
+			targetVal.Elem().Set(reflect.ValueOf(e))
+			found = true
+			return true
+		}
+		if tk, ok := e.(errbase.TypeKeyer); ok && tk.ErrbaseTypeKey() == targetKey {
+			targetVal.Elem().Set(reflect.Zero(targetType))
+			var payload interface{}
+			if pc, ok := e.(errbase.PayloadCarrier); ok {
+				payload = pc.ErrbasePayload()
+			}
+			if rv, ok := errbase.DecodeLeafByKey(targetKey, e.Error(), payload); ok {
+				if rt := reflect.TypeOf(rv); rt != nil && rt.AssignableTo(targetType) {
+					targetVal.Elem().Set(reflect.ValueOf(rv))
+				}
+			}
+			found = true
+			return true
+		}
+		return false
+	})
+	return found
+}
+
+// HasType reports whether err's chain contains a node whose concrete
+// type is that of reference, or whose preserved type fingerprint
+// corresponds to it. Pass a nil pointer of the type being searched
+// for, the same way IsType is used, e.g.
+// HasType(err, (*pq.Error)(nil)).
+//
+// HasType is the way to detect the presence of a type across the
+// network when no decoder is registered for it, and therefore no
+// value of that type can actually be produced; see As.
+func HasType(err error, reference error) bool {
+	if err == nil {
+		return false
+	}
+	refType := reflect.TypeOf(reference)
+	if refType == nil {
+		return false
+	}
+	refKey := errbase.GetTypeKeyForType(refType)
+
+	found := false
+	Walk(err, func(e error) bool {
+		if reflect.TypeOf(e) == refType {
+			found = true
+			return true
+		}
+		if tk, ok := e.(errbase.TypeKeyer); ok && tk.ErrbaseTypeKey() == refKey {
+			found = true
+			return true
+		}
+		return false
+	})
+	return found
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// MarkWithFields is like Mark, but additionally attaches a set of
+// structured fields (e.g. a request ID, a tenant ID) and a
+// user-facing message to the error. It gives call sites one idiomatic
+// way to annotate an error without defining a bespoke wrapper type
+// for every field they want to carry.
+//
+// Both the fields and the user message survive EncodeError/DecodeError,
+// and are collected from the single-cause chain by Fields and
+// UserMessage respectively.
+func MarkWithFields(err error, mark error, fields map[string]interface{}, userMessage string) error {
+	if err == nil {
+		return nil
+	}
+	return &withMarkFields{
+		withMark:    withMark{cause: err, reference: mark},
+		fields:      fields,
+		userMessage: userMessage,
+	}
+}
+
+// withMarkFields is a *withMark that additionally carries structured
+// fields and a user-facing message.
+type withMarkFields struct {
+	withMark
+	fields      map[string]interface{}
+	userMessage string
+}
+
+var _ errbase.Formatter = (*withMarkFields)(nil)
+var _ errbase.PayloadEncoder = (*withMarkFields)(nil)
+
+func (w *withMarkFields) markFields() map[string]interface{} { return w.fields }
+func (w *withMarkFields) markUserMessage() string             { return w.userMessage }
+
+func (w *withMarkFields) Format(s fmt.State, verb rune) { errbase.FormatError(w, s, verb) }
+
+func (w *withMarkFields) FormatError(p errbase.Printer) error {
+	p.Print("error with mark override")
+	if p.Detail() {
+		p.Printf("%q\n%s::", w.reference.Error(), errbase.GetTypeKey(w.reference))
+		if w.userMessage != "" {
+			p.Printf("\nuser message: %s", w.userMessage)
+		}
+		for _, k := range sortedKeys(w.fields) {
+			p.Printf("\n%s: %v", k, w.fields[k])
+		}
+	}
+	return w.cause
+}
+
+type markFieldsPayload struct {
+	Mark        markKey
+	Fields      map[string]interface{}
+	UserMessage string
+}
+
+func (w *withMarkFields) EncodePayload() interface{} {
+	return markFieldsPayload{
+		Mark:        getMark(w.reference),
+		Fields:      w.fields,
+		UserMessage: w.userMessage,
+	}
+}
+
+func init() {
+	key := errbase.GetTypeKey(&withMarkFields{})
+	errbase.RegisterWrapperDecoder(key, func(cause error, msg string, payload interface{}) error {
+		p, _ := payload.(markFieldsPayload)
+		return &withMarkFields{
+			withMark:    withMark{cause: cause, reference: &decodedMark{p.Mark}},
+			fields:      p.Fields,
+			userMessage: p.UserMessage,
+		}
+	})
+}
+
+// fieldCarrier is implemented by wrappers, such as *withMarkFields,
+// that attach structured fields to an error.
+type fieldCarrier interface {
+	error
+	markFields() map[string]interface{}
+}
+
+// userMessageCarrier is implemented by wrappers, such as
+// *withMarkFields, that attach a user-facing message to an error.
+type userMessageCarrier interface {
+	error
+	markUserMessage() string
+}
+
+// Fields collects the structured fields attached to err and its
+// causes via MarkWithFields. Fields set by an outer wrapper take
+// precedence over same-named fields set by an inner one, the same way
+// an outer context.Context value shadows an inner one. Only
+// single-cause wrapping (Unwrap() error) is followed; the children of
+// a multi-cause error, such as one produced by errors.Join, are not
+// searched.
+func Fields(err error) map[string]interface{} {
+	var chain []error
+	for e := err; e != nil; e = errbase.UnwrapOnce(e) {
+		chain = append(chain, e)
+	}
+
+	result := map[string]interface{}{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if fc, ok := chain[i].(fieldCarrier); ok {
+			for k, v := range fc.markFields() {
+				result[k] = v
+			}
+		}
+	}
+	return result
+}
+
+// UserMessage returns the first non-empty user-facing message
+// attached to err or one of its causes via MarkWithFields, searching
+// from the outside in, or the empty string if there is none. As with
+// Fields, the children of a multi-cause error are not searched.
+func UserMessage(err error) string {
+	for e := err; e != nil; e = errbase.UnwrapOnce(e) {
+		if mc, ok := e.(userMessageCarrier); ok {
+			if m := mc.markUserMessage(); m != "" {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
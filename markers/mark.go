@@ -0,0 +1,83 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// Mark returns a new error with the same Error() message as err and
+// err as its cause, but whose markKey (and therefore its behavior under Is) is
+// that of mark instead of err's own type and message. It is the way
+// to make two structurally different errors compare equal, or two
+// structurally identical errors compare different, under Is.
+func Mark(err error, mark error) error {
+	if err == nil {
+		return nil
+	}
+	return &withMark{cause: err, reference: mark}
+}
+
+// withMark overrides the markKey of its cause with that of reference,
+// without otherwise changing its message or its place in the chain.
+type withMark struct {
+	cause     error
+	reference error
+}
+
+var _ error = (*withMark)(nil)
+var _ errbase.Formatter = (*withMark)(nil)
+var _ errbase.PayloadEncoder = (*withMark)(nil)
+
+func (w *withMark) Error() string         { return w.cause.Error() }
+func (w *withMark) Unwrap() error         { return w.cause }
+func (w *withMark) markReference() error { return w.reference }
+
+func (w *withMark) Format(s fmt.State, verb rune) { errbase.FormatError(w, s, verb) }
+
+func (w *withMark) FormatError(p errbase.Printer) error {
+	p.Print("error with mark override")
+	if p.Detail() {
+		p.Printf("%q\n%s::", w.reference.Error(), errbase.GetTypeKey(w.reference))
+	}
+	return w.cause
+}
+
+// EncodePayload preserves the markKey of the reference mark so that
+// the mark override survives EncodeError/DecodeError.
+func (w *withMark) EncodePayload() interface{} {
+	return getMark(w.reference)
+}
+
+func init() {
+	key := errbase.GetTypeKey(&withMark{})
+	errbase.RegisterWrapperDecoder(key, func(cause error, msg string, payload interface{}) error {
+		m, _ := payload.(markKey)
+		return &withMark{cause: cause, reference: &decodedMark{m}}
+	})
+}
+
+// decodedMark stands in, after DecodeError, for the reference error a
+// remote Mark() call was made against. It has no concrete identity of
+// its own beyond the markKey it carries.
+type decodedMark struct {
+	m markKey
+}
+
+func (d *decodedMark) Error() string                   { return d.m.msg }
+func (d *decodedMark) ErrbaseTypeKey() errbase.TypeKey { return d.m.key }
+func (d *decodedMark) markerID() string                { return d.m.id }
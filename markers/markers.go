@@ -0,0 +1,230 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package markers provides a notion of error equivalence, Is(), that
+// is preserved across the network: two errors that started out in
+// different processes but were constructed the same way (same Go
+// type, same message, same wrapping) are considered equivalent, and
+// this equivalence can also be overridden explicitly with Mark().
+package markers
+
+import (
+	"reflect"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// markKey is the value used to decide whether two errors are
+// equivalent: see equal. It is always computed from a real error by
+// getMark/leafMark, which populate either id or both key and msg;
+// there is no zero-value markKey in practice.
+type markKey struct {
+	key errbase.TypeKey
+	msg string
+	// id is set instead of key/msg for errors created through
+	// RegisterMarker, whose identity is the registered id rather than
+	// their type and message.
+	id string
+}
+
+// equal reports whether two markKeys identify the same error. When
+// either side has a registered id, only the id is compared, so that a
+// registered marker's identity does not depend on its type or
+// message.
+func (m markKey) equal(o markKey) bool {
+	if m.id != "" || o.id != "" {
+		return m.id == o.id
+	}
+	return m.key == o.key && m.msg == o.msg
+}
+
+// markOverrider is implemented by any wrapper that substitutes its own
+// markKey with that of a reference error, such as *withMark and
+// *withMarkFields.
+type markOverrider interface {
+	error
+	markReference() error
+}
+
+// markerIDer is implemented by errors created through RegisterMarker
+// (and by their decoded, post-network stand-ins), reporting the
+// stable id that determines their markKey.
+type markerIDer interface {
+	error
+	markerID() string
+}
+
+// getMark computes the markKey of a single error node. A node that
+// overrides its mark (see markOverrider) defers entirely to the
+// markKey of the reference it was given, ignoring its own type and
+// message. A node with a registered id (see markerIDer) uses that id
+// instead of its type and message.
+func getMark(err error) markKey {
+	if m, ok := err.(markOverrider); ok {
+		return getMark(m.markReference())
+	}
+	if mi, ok := err.(markerIDer); ok {
+		if id := mi.markerID(); id != "" {
+			return markKey{id: id}
+		}
+	}
+	return markKey{key: errbase.GetTypeKey(err), msg: err.Error()}
+}
+
+// Is determines whether err is equivalent to reference: whether err,
+// or any of its causes, is the root of a chain that is itself
+// equivalent to reference's entire chain -- same markKey at every
+// depth, not merely at the outermost layer. Causes are followed both
+// through a single Unwrap() error and, for errors produced by
+// errors.Join or fmt.Errorf's multi-%w, through Unwrap() []error, so
+// that any branch of the cause tree can make the match.
+//
+// Comparing the full chain, rather than just the candidate node's own
+// markKey, matters because two unrelated wrapper types can coincide
+// on type and message at one layer while diverging underneath -- see
+// TestMaskedErrorEquivalence.
+//
+// Is behaves like the standard library's errors.Is, except that it
+// additionally considers two errors equivalent when they were
+// constructed independently (e.g. in different processes) but have
+// the same Go type and message, or when they have been explicitly
+// linked together with Mark.
+func Is(err, reference error) bool {
+	if err == nil || reference == nil {
+		return err == reference
+	}
+	found := false
+	Walk(err, func(e error) bool {
+		found = chainEqual(e, reference)
+		return found
+	})
+	return found
+}
+
+// chainEqual reports whether a and b are the roots of equivalent
+// chains: the same markKey at every depth, recursing into multi-cause
+// children pairwise when present, or into the single cause otherwise.
+// A markOverrider node is resolved to its reference before being
+// compared or descended into, so that e.g. Mark(x, m) is equivalent,
+// chain and all, to m itself rather than to x.
+func chainEqual(a, b error) bool {
+	for {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		if m, ok := a.(markOverrider); ok {
+			a = m.markReference()
+			continue
+		}
+		if m, ok := b.(markOverrider); ok {
+			b = m.markReference()
+			continue
+		}
+		if !leafMark(a).equal(leafMark(b)) {
+			return false
+		}
+
+		aChildren := errbase.UnwrapMulti(a)
+		bChildren := errbase.UnwrapMulti(b)
+		if aChildren != nil || bChildren != nil {
+			if len(aChildren) != len(bChildren) {
+				return false
+			}
+			for i := range aChildren {
+				if !chainEqual(aChildren[i], bChildren[i]) {
+					return false
+				}
+			}
+			return true
+		}
+
+		a, b = errbase.UnwrapOnce(a), errbase.UnwrapOnce(b)
+	}
+}
+
+// leafMark computes the markKey of a single error node, without
+// consulting markOverrider -- callers that need override resolution
+// across an entire chain, such as chainEqual, must resolve it
+// themselves before calling leafMark so that the resolved node's own
+// cause chain (if any) is also visited.
+func leafMark(err error) markKey {
+	if mi, ok := err.(markerIDer); ok {
+		if id := mi.markerID(); id != "" {
+			return markKey{id: id}
+		}
+	}
+	return markKey{key: errbase.GetTypeKey(err), msg: err.Error()}
+}
+
+// IsType determines whether err, or any of its causes, has exactly
+// the Go type of reference. Like Walk, it also checks the reference
+// error of any Mark() override found in the chain.
+func IsType(err error, reference error) bool {
+	if err == nil {
+		return false
+	}
+	refType := reflect.TypeOf(reference)
+	found := false
+	Walk(err, func(e error) bool {
+		found = reflect.TypeOf(e) == refType
+		return found
+	})
+	return found
+}
+
+// Walk performs a depth-first traversal of err's cause tree, calling
+// visit on every node -- err itself, then its causes, recursively --
+// until visit returns true or the tree is exhausted, and reports
+// whether some node made it return true. It follows both
+// single-cause (Unwrap() error) and multi-cause (Unwrap() []error)
+// wrapping, guards against cycles, and, for a node that overrides its
+// mark (see markOverrider), also visits the reference it was given --
+// the same mark-override semantics already used by Is -- before
+// continuing into the node's own cause.
+//
+// Downstream code can use Walk to implement custom error
+// classification (e.g. "does this chain carry any retryable mark?")
+// without reimplementing cause-tree traversal; Is, IsType, As and
+// HasType are themselves built on it.
+func Walk(err error, visit func(error) bool) bool {
+	return walk(err, make(map[error]bool), visit)
+}
+
+func walk(err error, seen map[error]bool, visit func(error) bool) bool {
+	if err == nil || seen[err] {
+		return false
+	}
+	seen[err] = true
+
+	if visit(err) {
+		return true
+	}
+
+	if m, ok := err.(markOverrider); ok {
+		if walk(m.markReference(), seen, visit) {
+			return true
+		}
+	}
+
+	if children := errbase.UnwrapMulti(err); children != nil {
+		for _, c := range children {
+			if walk(c, seen, visit) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return walk(errbase.UnwrapOnce(err), seen, visit)
+}
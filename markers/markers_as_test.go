@@ -0,0 +1,81 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// pqError stands in for a driver error type, like *pq.Error, that has
+// a decoder registered on the receiving side of the network.
+type pqError struct {
+	Code string
+}
+
+func (e *pqError) Error() string { return "pq: " + e.Code }
+
+func init() {
+	errbase.RegisterLeafDecoder(errbase.GetTypeKey(&pqError{}), func(msg string, _ interface{}) error {
+		return &pqError{Code: strings.TrimPrefix(msg, "pq: ")}
+	})
+}
+
+// unregisteredDriverError stands in for an error type that the
+// receiving binary never registers a decoder for, simulating a
+// service that wants to branch on a type from a package it does not
+// link in.
+type unregisteredDriverError struct {
+	Detail string
+}
+
+func (e *unregisteredDriverError) Error() string { return "driver: " + e.Detail }
+
+// This test demonstrates that As() recovers the concrete type of an
+// error that crossed the network, as long as a decoder is registered
+// for it.
+func TestAsAcrossNetworkWithDecoder(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	orig := &pqError{Code: "23505"}
+	wire := network(orig)
+
+	var target *pqError
+	tt.Check(markers.As(wire, &target))
+	tt.Check(target != nil)
+	tt.CheckEqual(target.Code, "23505")
+}
+
+// This test demonstrates that As() still recognizes an error's type
+// fingerprint across the network even when no decoder is registered,
+// and that HasType exposes the same recognition without requiring a
+// reconstructed value.
+func TestAsAcrossNetworkWithoutDecoder(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	orig := &unregisteredDriverError{Detail: "boom"}
+	wire := network(orig)
+
+	var target *unregisteredDriverError
+	tt.Check(markers.As(wire, &target))
+	tt.Check(target == nil)
+
+	tt.Check(markers.HasType(wire, (*unregisteredDriverError)(nil)))
+	tt.Check(!markers.HasType(wire, (*pqError)(nil)))
+}
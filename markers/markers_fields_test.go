@@ -0,0 +1,83 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	goErr "errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// This test demonstrates that Fields() collects fields from the whole
+// cause chain, with an outer MarkWithFields call overriding a
+// same-named field set by an inner one, and that UserMessage() finds
+// the first non-empty message starting from the outside.
+func TestFieldsAndUserMessage(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	inner := markers.MarkWithFields(goErr.New("boom"), goErr.New("m1"),
+		map[string]interface{}{"request_id": "r1", "tenant_id": "t1"}, "inner message")
+	outer := markers.MarkWithFields(inner, goErr.New("m2"),
+		map[string]interface{}{"tenant_id": "t2"}, "")
+
+	fields := markers.Fields(outer)
+	tt.CheckEqual(fields["request_id"], "r1")
+	tt.CheckEqual(fields["tenant_id"], "t2")
+
+	tt.CheckEqual(markers.UserMessage(outer), "inner message")
+}
+
+// This test demonstrates that fields and the user message survive
+// EncodeError/DecodeError, alongside the mark override itself.
+func TestFieldsSurviveNetwork(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	m := goErr.New("mark")
+	err := markers.MarkWithFields(goErr.New("boom"), m,
+		map[string]interface{}{"a": "1"}, "hello user")
+
+	wire := network(err)
+
+	tt.Check(markers.Is(wire, m))
+	tt.CheckEqual(markers.Fields(wire)["a"], "1")
+	tt.CheckEqual(markers.UserMessage(wire), "hello user")
+}
+
+// This test demonstrates that the verbose format of a
+// MarkWithFields error prints the user message and the fields, in
+// sorted key order, in its detail section.
+func TestFormatWithFields(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	refErr := goErr.New("foo")
+	err := markers.MarkWithFields(goErr.New("woo"), refErr,
+		map[string]interface{}{"b": 2, "a": 1}, "something went wrong")
+
+	tt.CheckEqual(fmt.Sprintf("%s", err), "woo")
+
+	const expVerbose = `
+error with mark override:
+    "foo"
+    errors/*errors.errorString::
+    user message: something went wrong
+    a: 1
+    b: 2
+  - woo`
+
+	tt.CheckEqual(fmt.Sprintf("%+v", err), expVerbose[1:])
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	goErr "errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+var (
+	errNotFound = markers.RegisterMarker("markers_test.ErrNotFound")
+	errTimeout  = markers.RegisterMarker("markers_test.ErrTimeout")
+	errDenied   = markers.RegisterMarker("markers_test.ErrDenied")
+)
+
+// This test demonstrates IsAny matching when err is equivalent to at
+// least one of several candidate references, and failing to match
+// when it is equivalent to none of them.
+func TestIsAny(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := markers.Mark(goErr.New("boom"), errTimeout)
+
+	tt.Check(markers.IsAny(err, errNotFound, errTimeout))
+	tt.Check(!markers.IsAny(err, errNotFound, errDenied))
+}
+
+// This test demonstrates IsAll matching only when err is equivalent
+// to every reference passed to it.
+func TestIsAll(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := markers.Mark(goErr.New("boom"), errTimeout)
+
+	tt.Check(markers.IsAll(err, errTimeout))
+	tt.Check(markers.IsAll(err, errTimeout, errTimeout))
+	tt.Check(!markers.IsAll(err, errTimeout, errNotFound))
+}
+
+// This test demonstrates using Match, and the lower-level Walk it is
+// built on, to implement a custom classification -- here, "is any
+// mark in this chain one of the errors we consider retryable" --
+// without reimplementing cause-tree traversal.
+func TestMatchCustomClassification(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	retryable := map[error]bool{errTimeout: true}
+	isRetryable := func(err error) bool {
+		return markers.Match(err, func(e error) bool { return retryable[e] })
+	}
+
+	timeoutErr := fmt.Errorf("request failed: %w", markers.Mark(goErr.New("deadline exceeded"), errTimeout))
+	deniedErr := fmt.Errorf("request failed: %w", markers.Mark(goErr.New("forbidden"), errDenied))
+
+	tt.Check(isRetryable(timeoutErr))
+	tt.Check(!isRetryable(deniedErr))
+}
+
+// This test demonstrates that Walk sees every branch of a joined
+// error's cause tree, not just the first.
+func TestWalkMultiCause(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	joined := goErr.Join(goErr.New("a"), goErr.New("b"), goErr.New("c"))
+
+	var seen []string
+	markers.Walk(joined, func(e error) bool {
+		seen = append(seen, e.Error())
+		return false
+	})
+
+	tt.CheckEqual(len(seen), 4)
+}
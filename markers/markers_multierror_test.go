@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// This test demonstrates that Is() can see into an error produced by
+// errors.Join, matching any of its children.
+func TestMultierrorIs(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	a := errors.New("a")
+	b := errors.New("b")
+	c := errors.New("c")
+	joined := errors.Join(a, b, c)
+
+	tt.Check(markers.Is(joined, a))
+	tt.Check(markers.Is(joined, b))
+	tt.Check(markers.Is(joined, c))
+	tt.Check(!markers.Is(joined, errors.New("d")))
+}
+
+// This test demonstrates the same traversal for fmt.Errorf's
+// multi-%w support.
+func TestMultierrorIsFmtErrorf(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	a := errors.New("a")
+	b := errors.New("b")
+	joined := fmt.Errorf("wrapping %w and %w", a, b)
+
+	tt.Check(markers.Is(joined, a))
+	tt.Check(markers.Is(joined, b))
+}
+
+// This test demonstrates that the equivalence of a joined error's
+// children is preserved across the network.
+func TestStandardFmtMultierrorRemoteEquivalence(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	a := errors.New("a")
+	b := errors.New("b")
+	c := errors.New("c")
+	joined := errors.Join(a, b, c)
+
+	newJoined := network(joined)
+
+	tt.Check(markers.Is(newJoined, a))
+	tt.Check(markers.Is(newJoined, b))
+	tt.Check(markers.Is(newJoined, c))
+	tt.Check(!markers.Is(newJoined, errors.New("d")))
+}
+
+// This test demonstrates that Mark() on a joined error attaches to
+// the outer wrapper and does not flatten, or otherwise disturb, the
+// equivalence of the individual children.
+func TestMarkOnJoinedErrorDoesNotFlattenChildren(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	a := errors.New("a")
+	b := errors.New("b")
+	joined := errors.Join(a, b)
+
+	m := errors.New("mark")
+	marked := markers.Mark(joined, m)
+
+	tt.Check(markers.Is(marked, m))
+	tt.Check(markers.Is(marked, a))
+	tt.Check(markers.Is(marked, b))
+}
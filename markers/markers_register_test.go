@@ -0,0 +1,58 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	goErr "errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// This test demonstrates that the equivalence of a registered marker
+// does not depend on the message of the errors it marks: two errors
+// wrapped under the same registered marker remain equivalent, locally
+// and across the network, even as the underlying message changes
+// between call sites (simulating a message rename across versions).
+func TestRegisteredMarkerStableAcrossRename(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	m := markers.RegisterMarker("markers_test.ErrStableExample")
+
+	v1 := markers.Mark(goErr.New("original message"), m)
+	v2 := markers.Mark(goErr.New("renamed message"), m)
+
+	tt.Check(markers.Is(v1, m))
+	tt.Check(markers.Is(v2, m))
+	tt.Check(markers.Is(v1, v2))
+
+	wire := network(v1)
+	tt.Check(markers.Is(wire, m))
+	tt.Check(markers.Is(wire, v2))
+}
+
+// This test demonstrates that registering the same marker id twice
+// panics, since it is almost always a copy-pasted id.
+func TestRegisterMarkerDuplicatePanics(t *testing.T) {
+	markers.RegisterMarker("markers_test.ErrDuplicateExample")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected RegisterMarker to panic on a duplicate id")
+		}
+	}()
+	markers.RegisterMarker("markers_test.ErrDuplicateExample")
+}
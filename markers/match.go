@@ -0,0 +1,52 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers
+
+// IsAny determines whether err is equivalent, under Is, to any of
+// refs. It is a shorthand for chaining Is with || across a list of
+// candidate references, e.g. checking a single error against several
+// known sentinels at once.
+func IsAny(err error, refs ...error) bool {
+	for _, ref := range refs {
+		if Is(err, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAll determines whether err is equivalent, under Is, to every one
+// of refs. Since Is matches at any node of err's cause tree, this can
+// hold for several references that are not equivalent to one another
+// -- e.g. the children of an errors.Join, or two distinct Mark()
+// overrides at different positions in the same chain.
+func IsAll(err error, refs ...error) bool {
+	for _, ref := range refs {
+		if !Is(err, ref) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether pred returns true for err or any of its
+// causes. It is the general form of Is and IsType, for callers that
+// need to classify errors by some criterion other than equivalence or
+// exact type -- for example, "does this chain carry any mark
+// registered as retryable?" -- without reimplementing cause-tree
+// traversal; see Walk.
+func Match(err error, pred func(error) bool) bool {
+	return Walk(err, pred)
+}
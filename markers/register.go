@@ -0,0 +1,90 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+var registeredMarkerIDs = struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}{ids: map[string]bool{}}
+
+// RegisterMarker returns a sentinel error whose equivalence under Is,
+// both locally and across the network, is determined solely by id,
+// through a fixed-size digest of it computed once at registration
+// time -- not by its Go type or its Error() message. The sentinel's
+// Error() message is id itself; no separate message can be set, so the
+// message does not change without the id, and thus the identity,
+// changing with it. Errors marked with the sentinel through Mark stay
+// equivalent to it, and to one another, whatever their own messages.
+//
+// id is meant to be unique process-wide, typically a dotted name such
+// as "mypkg.ErrNotFound". RegisterMarker panics if id has already
+// been registered, by this package or any other, which is almost
+// always a copy-pasted id and a bug. It is meant to be called from
+// package-level var initializers or init functions, where such a
+// panic surfaces immediately at program startup.
+func RegisterMarker(id string) error {
+	registeredMarkerIDs.mu.Lock()
+	defer registeredMarkerIDs.mu.Unlock()
+
+	if registeredMarkerIDs.ids[id] {
+		panic(fmt.Sprintf("errors: marker id %q registered more than once", id))
+	}
+	registeredMarkerIDs.ids[id] = true
+
+	return &registeredMarker{id: markerDigest(id), msg: id}
+}
+
+// registeredMarker is the sentinel returned by RegisterMarker.
+type registeredMarker struct {
+	id  string
+	msg string
+}
+
+var _ error = (*registeredMarker)(nil)
+var _ errbase.PayloadEncoder = (*registeredMarker)(nil)
+
+func (m *registeredMarker) Error() string    { return m.msg }
+func (m *registeredMarker) markerID() string { return m.id }
+
+// EncodePayload preserves the registered id so that the marker's
+// stable identity, not just a Mark() override of it, survives
+// EncodeError/DecodeError even when the marker itself is sent as the
+// error, not merely referenced from a withMark.
+func (m *registeredMarker) EncodePayload() interface{} { return m.id }
+
+func init() {
+	key := errbase.GetTypeKey(&registeredMarker{})
+	errbase.RegisterLeafDecoder(key, func(msg string, payload interface{}) error {
+		id, _ := payload.(string)
+		return &decodedMark{m: markKey{id: id, msg: msg}}
+	})
+}
+
+// markerDigest turns a caller-chosen id into the fixed-size digest
+// actually used as the markKey id, so that the wire representation of
+// a mark never grows with the length of the id string callers chose.
+func markerDigest(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
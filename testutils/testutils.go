@@ -0,0 +1,50 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package testutils provides small helpers shared by this repository's
+// test suites.
+package testutils
+
+import "testing"
+
+// T wraps *testing.T with a couple of terse assertion helpers, so
+// that tests read as a flat sequence of checks.
+type T struct {
+	*testing.T
+}
+
+// Check reports a test failure if cond is false.
+func (t T) Check(cond bool) {
+	t.Helper()
+	if !cond {
+		t.Error("check failed")
+	}
+}
+
+// CheckEqual reports a test failure if got != want.
+func (t T) CheckEqual(got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("unexpected value:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+// Run wraps (*testing.T).Run, passing a T to fn instead of a
+// *testing.T.
+func (t T) Run(name string, fn func(T)) {
+	t.Helper()
+	t.T.Run(name, func(st *testing.T) {
+		fn(T{st})
+	})
+}